@@ -0,0 +1,214 @@
+// Package api exposes a Player and the SomaFM channel directory over
+// HTTP so somafm-cli can be scripted or driven from a headless box. It
+// wraps the same player.Player the TUI uses, so behavior is identical in
+// both modes.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/streamerd/somafm-cli-go/player"
+	"github.com/streamerd/somafm-cli-go/somafm"
+)
+
+// Server serves the JSON control API and the /events SSE stream.
+type Server struct {
+	player   *player.Player
+	channels []somafm.Channel
+
+	mu       sync.Mutex
+	selected *somafm.Channel
+	clients  map[chan []byte]struct{}
+}
+
+// New builds a Server around an existing player. p should not be driven
+// from anywhere else while the server owns it.
+func New(p *player.Player, channels []somafm.Channel) *Server {
+	s := &Server{
+		player:   p,
+		channels: channels,
+		clients:  make(map[chan []byte]struct{}),
+	}
+
+	p.OnNowPlaying(func(artist, title string) {
+		s.broadcast("now_playing", map[string]string{"artist": artist, "title": title})
+	})
+
+	return s
+}
+
+// Routes returns the handler serving every endpoint.
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/channels", s.handleChannels)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/play/", s.handlePlay)
+	mux.HandleFunc("/stop", s.handleStop)
+	mux.HandleFunc("/volume/", s.handleVolume)
+	mux.HandleFunc("/events", s.handleEvents)
+	return mux
+}
+
+func (s *Server) handleChannels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.channels)
+}
+
+type statusResponse struct {
+	State     string `json:"state"`
+	Channel   string `json:"channel,omitempty"`
+	Volume    int    `json:"volume"`
+	Recording bool   `json:"recording"`
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	selected := s.selected
+	s.mu.Unlock()
+
+	resp := statusResponse{State: stateString(s.player.State()), Volume: s.player.VolumePercent()}
+	if selected != nil {
+		resp.Channel = selected.ID
+	}
+	_, _, resp.Recording = s.player.RecordingStats()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	channelID := strings.TrimPrefix(r.URL.Path, "/play/")
+	channel, ok := somafm.Find(s.channels, channelID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown channel %q", channelID), http.StatusNotFound)
+		return
+	}
+
+	streamURL, err := somafm.StreamURL(channel.FastPLS)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if err := s.player.Select(streamURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	s.mu.Lock()
+	s.selected = &channel
+	s.mu.Unlock()
+
+	s.broadcast("state", statusResponse{State: stateString(s.player.State()), Channel: channel.ID})
+	writeJSON(w, http.StatusOK, map[string]string{"channel": channel.ID})
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.player.Stop()
+
+	s.mu.Lock()
+	s.selected = nil
+	s.mu.Unlock()
+
+	s.broadcast("state", statusResponse{State: stateString(s.player.State())})
+	writeJSON(w, http.StatusOK, map[string]string{"state": "stopped"})
+}
+
+func (s *Server) handleVolume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pct, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/volume/"))
+	if err != nil || pct < 0 || pct > 100 {
+		http.Error(w, "volume must be an integer between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	s.player.SetVolumePercent(pct)
+
+	writeJSON(w, http.StatusOK, map[string]int{"volume": pct})
+}
+
+// handleEvents streams state changes and now-playing updates as
+// server-sent events until the client disconnects.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan []byte, 8)
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg := <-ch:
+			w.Write(msg)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) broadcast(event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	msg := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", event, payload))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		select {
+		case c <- msg:
+		default: // client is slow; drop rather than block the player
+		}
+	}
+}
+
+func stateString(st player.State) string {
+	switch st {
+	case player.Playing:
+		return "playing"
+	case player.Paused:
+		return "paused"
+	default:
+		return "stopped"
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,168 @@
+// Package config persists somafm-cli's local state: starred channels,
+// the last channel played, remembered per-channel volume, and play
+// history. It lives under XDG_CONFIG_HOME (or ~/.config) so it survives
+// across runs without touching anything SomaFM-side.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	dirName    = "somafm-cli"
+	fileName   = "state.json"
+	maxHistory = 100
+)
+
+// HistoryEntry records one play of a channel.
+type HistoryEntry struct {
+	ChannelID string    `json:"channel_id"`
+	PlayedAt  time.Time `json:"played_at"`
+}
+
+// State is the full contents of state.json.
+type State struct {
+	Favorites   map[string]bool `json:"favorites"`
+	LastChannel string          `json:"last_channel"`
+	Volumes     map[string]int  `json:"volumes"`
+	History     []HistoryEntry  `json:"history"`
+
+	path string
+}
+
+// Dir returns the somafm-cli config directory, respecting
+// XDG_CONFIG_HOME.
+func Dir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base != "" {
+		return filepath.Join(base, dirName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", dirName), nil
+}
+
+// Load reads state.json, returning an empty State if it doesn't exist
+// yet.
+func Load() (*State, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fileName)
+
+	s := &State{
+		Favorites: make(map[string]bool),
+		Volumes:   make(map[string]int),
+		path:      path,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	s.path = path
+	if s.Favorites == nil {
+		s.Favorites = make(map[string]bool)
+	}
+	if s.Volumes == nil {
+		s.Volumes = make(map[string]int)
+	}
+	return s, nil
+}
+
+// Save atomically writes state.json: it writes to a temp file in the
+// same directory, then renames it over the target, so a crash mid-write
+// can't leave a corrupt file behind.
+func (s *State) Save() error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, fileName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// ToggleFavorite stars channelID, or unstars it if it's already starred.
+func (s *State) ToggleFavorite(channelID string) {
+	if s.Favorites[channelID] {
+		delete(s.Favorites, channelID)
+		return
+	}
+	s.Favorites[channelID] = true
+}
+
+// IsFavorite reports whether channelID is starred.
+func (s *State) IsFavorite(channelID string) bool {
+	return s.Favorites[channelID]
+}
+
+// SetVolume remembers channelID's volume as a 0-100 percentage.
+func (s *State) SetVolume(channelID string, percent int) {
+	s.Volumes[channelID] = percent
+}
+
+// Volume returns channelID's remembered volume, if any was saved.
+func (s *State) Volume(channelID string) (percent int, ok bool) {
+	percent, ok = s.Volumes[channelID]
+	return percent, ok
+}
+
+// RecordPlay remembers channelID as the last played channel and appends
+// it to history, trimming history to the most recent maxHistory plays.
+func (s *State) RecordPlay(channelID string, when time.Time) {
+	s.LastChannel = channelID
+	s.History = append(s.History, HistoryEntry{ChannelID: channelID, PlayedAt: when})
+	if len(s.History) > maxHistory {
+		s.History = s.History[len(s.History)-maxHistory:]
+	}
+}
+
+// RecentChannelIDs returns played channel IDs, most recent first, with
+// duplicates removed.
+func (s *State) RecentChannelIDs() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for i := len(s.History) - 1; i >= 0; i-- {
+		id := s.History[i].ChannelID
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
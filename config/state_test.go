@@ -0,0 +1,100 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadSaveRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s, err := Load()
+	if err != nil {
+		t.Fatalf("Load (fresh): %v", err)
+	}
+
+	s.ToggleFavorite("groovesalad")
+	s.SetVolume("groovesalad", 42)
+	s.RecordPlay("groovesalad", time.Unix(1000, 0))
+	s.RecordPlay("dronezone", time.Unix(2000, 0))
+
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load (reloaded): %v", err)
+	}
+
+	if !reloaded.IsFavorite("groovesalad") {
+		t.Error("IsFavorite(groovesalad) = false after reload, want true")
+	}
+	if pct, ok := reloaded.Volume("groovesalad"); !ok || pct != 42 {
+		t.Errorf("Volume(groovesalad) = (%d, %v), want (42, true)", pct, ok)
+	}
+	if reloaded.LastChannel != "dronezone" {
+		t.Errorf("LastChannel = %q, want %q", reloaded.LastChannel, "dronezone")
+	}
+}
+
+func TestLoadMissingFileReturnsEmptyState(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if s.IsFavorite("anything") {
+		t.Error("IsFavorite on fresh state = true, want false")
+	}
+	if _, ok := s.Volume("anything"); ok {
+		t.Error("Volume on fresh state ok = true, want false")
+	}
+}
+
+func TestToggleFavorite(t *testing.T) {
+	s := &State{Favorites: make(map[string]bool)}
+
+	s.ToggleFavorite("groovesalad")
+	if !s.IsFavorite("groovesalad") {
+		t.Fatal("IsFavorite = false after first toggle, want true")
+	}
+
+	s.ToggleFavorite("groovesalad")
+	if s.IsFavorite("groovesalad") {
+		t.Fatal("IsFavorite = true after second toggle, want false")
+	}
+}
+
+func TestRecentChannelIDsDedupsMostRecentFirst(t *testing.T) {
+	s := &State{Favorites: make(map[string]bool), Volumes: make(map[string]int)}
+
+	s.RecordPlay("a", time.Unix(1, 0))
+	s.RecordPlay("b", time.Unix(2, 0))
+	s.RecordPlay("a", time.Unix(3, 0))
+	s.RecordPlay("c", time.Unix(4, 0))
+
+	got := s.RecentChannelIDs()
+	want := []string{"c", "a", "b"}
+	if len(got) != len(want) {
+		t.Fatalf("RecentChannelIDs = %v, want %v", got, want)
+	}
+	for i, id := range want {
+		if got[i] != id {
+			t.Errorf("RecentChannelIDs[%d] = %q, want %q", i, got[i], id)
+		}
+	}
+}
+
+func TestRecordPlayTrimsHistory(t *testing.T) {
+	s := &State{Favorites: make(map[string]bool), Volumes: make(map[string]int)}
+
+	for i := 0; i < maxHistory+10; i++ {
+		s.RecordPlay("ch", time.Unix(int64(i), 0))
+	}
+
+	if len(s.History) != maxHistory {
+		t.Fatalf("len(History) = %d, want %d", len(s.History), maxHistory)
+	}
+}
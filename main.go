@@ -1,37 +1,21 @@
 package main
 
 import (
-	"bufio"
-	"encoding/xml"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
-	"os/exec"
-	"regexp"
-	"strings"
+	"path/filepath"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"golang.org/x/net/html/charset"
-)
-
-// Channel represents a SomaFM channel
-type Channel struct {
-	ID          string `xml:"id,attr"`
-	Title       string `xml:"title"`
-	Description string `xml:"description"`
-	Genre       string `xml:"genre"`
-	Image       string `xml:"image"`
-	DJ          string `xml:"dj"`
-	Listeners   int    `xml:"listeners"`
-	FastPLS     string `xml:"fastpls"`
-}
 
-// Channels represents the root XML element
-type Channels struct {
-	XMLName  xml.Name  `xml:"channels"`
-	Channels []Channel `xml:"channel"`
-}
+	"github.com/streamerd/somafm-cli-go/api"
+	"github.com/streamerd/somafm-cli-go/config"
+	"github.com/streamerd/somafm-cli-go/player"
+	"github.com/streamerd/somafm-cli-go/somafm"
+)
 
 type playerState int
 
@@ -43,15 +27,41 @@ const (
 	statsWidth = 10
 )
 
+// viewMode selects which subset of channels the list shows.
+type viewMode int
+
+const (
+	viewAll viewMode = iota
+	viewFavorites
+	viewRecent
+)
+
+func (v viewMode) String() string {
+	switch v {
+	case viewFavorites:
+		return "Favorites"
+	case viewRecent:
+		return "Recent"
+	default:
+		return "All"
+	}
+}
+
 // model represents the application state
 type model struct {
-	channels    []Channel
+	channels    []somafm.Channel
 	cursor      int
-	selected    *Channel
+	view        viewMode
+	selected    *somafm.Channel
 	err         error
 	loading     bool
 	playerState playerState
-	player      *exec.Cmd
+	player      *player.Player
+	recordDir   string
+	resume      bool
+	Recording   *os.File
+	nowPlaying  nowPlayingMsg
+	cfg         *config.State
 }
 
 // Define some basic styling
@@ -67,88 +77,125 @@ var (
 			Foreground(lipgloss.Color("#FF0000"))
 )
 
-func initialModel() model {
+func initialModel(recordDir string, resume bool, cfg *config.State) model {
 	return model{
-		loading: true,
+		loading:   true,
+		player:    player.New(),
+		recordDir: recordDir,
+		resume:    resume,
+		cfg:       cfg,
+	}
+}
+
+// visibleChannels returns the channels the current view should list,
+// degrading gracefully (just omitting the entry) if a favorited or
+// recently-played channel ID no longer appears in channels.xml.
+func (m model) visibleChannels() []somafm.Channel {
+	switch m.view {
+	case viewFavorites:
+		var out []somafm.Channel
+		for _, ch := range m.channels {
+			if m.cfg.IsFavorite(ch.ID) {
+				out = append(out, ch)
+			}
+		}
+		return out
+
+	case viewRecent:
+		var out []somafm.Channel
+		for _, id := range m.cfg.RecentChannelIDs() {
+			if ch, ok := somafm.Find(m.channels, id); ok {
+				out = append(out, ch)
+			}
+		}
+		return out
+
+	default:
+		return m.channels
 	}
 }
 
 // Message types
-type channelsMsg []Channel
+type channelsMsg []somafm.Channel
 type errMsg struct{ error }
-type startPlaybackMsg struct {
-	player *exec.Cmd
-}
+type startPlaybackMsg struct{}
 type stopPlaybackMsg struct{}
 type playbackErrorMsg struct{ error }
+type startRecordingMsg struct{ file *os.File }
+type stopRecordingMsg struct{}
+type recordingErrorMsg struct{ error }
+type nowPlayingMsg struct{ artist, title string }
+
+// indexByID returns the index of the channel with the given ID, or false
+// if a saved channel no longer appears in channels.xml.
+func indexByID(channels []somafm.Channel, id string) (int, bool) {
+	for i, ch := range channels {
+		if ch.ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
 
 func fetchChannels() tea.Msg {
-	resp, err := http.Get("https://somafm.com/channels.xml")
+	channels, err := somafm.FetchChannels()
 	if err != nil {
 		return errMsg{err}
 	}
-	defer resp.Body.Close()
-
-	decoder := xml.NewDecoder(resp.Body)
-	decoder.CharsetReader = charset.NewReaderLabel
-
-	var channels Channels
-	if err := decoder.Decode(&channels); err != nil {
-		return errMsg{err}
-	}
+	return channelsMsg(channels)
+}
 
-	for i := range channels.Channels {
-		channels.Channels[i].Description = strings.TrimSpace(channels.Channels[i].Description)
-		if fastPLS := channels.Channels[i].FastPLS; fastPLS != "" {
-			if idx := strings.Index(fastPLS, "\n"); idx != -1 {
-				channels.Channels[i].FastPLS = fastPLS[:idx]
-			}
+// Command to start playback by mixing streamURL into p
+func startPlayback(p *player.Player, streamURL string) tea.Cmd {
+	return func() tea.Msg {
+		if err := p.Select(streamURL); err != nil {
+			return playbackErrorMsg{err}
 		}
+		return startPlaybackMsg{}
 	}
-
-	return channelsMsg(channels.Channels)
 }
 
-// Function to parse PLS and get stream URL
-func getStreamURL(plsURL string) (string, error) {
-	resp, err := http.Get(plsURL)
-	if err != nil {
-		return "", err
+// Command to stop playback
+func stopPlayback(p *player.Player) tea.Cmd {
+	return func() tea.Msg {
+		p.Stop()
+		return stopPlaybackMsg{}
 	}
-	defer resp.Body.Close()
-
-	scanner := bufio.NewScanner(resp.Body)
-	re := regexp.MustCompile(`File1=(.+)`)
+}
 
-	for scanner.Scan() {
-		if matches := re.FindStringSubmatch(scanner.Text()); len(matches) > 1 {
-			return matches[1], nil
+// Command to start recording the currently playing channel to dir
+func startRecording(p *player.Player, dir, channelID string) tea.Cmd {
+	return func() tea.Msg {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return recordingErrorMsg{err}
 		}
-	}
 
-	return "", fmt.Errorf("no stream URL found in PLS file")
-}
+		name := fmt.Sprintf("%s-%d.mp3", channelID, time.Now().Unix())
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return recordingErrorMsg{err}
+		}
 
-// Command to start playback using MPV
-func startPlayback(streamURL string) tea.Cmd {
-	return func() tea.Msg {
-		cmd := exec.Command("mpv", streamURL, "--no-terminal")
-		if err := cmd.Start(); err != nil {
-			return playbackErrorMsg{err}
+		if err := p.StartRecording(file); err != nil {
+			file.Close()
+			return recordingErrorMsg{err}
 		}
-		return startPlaybackMsg{cmd}
+
+		return startRecordingMsg{file}
 	}
 }
 
-// Command to stop playback
-func stopPlayback(cmd *exec.Cmd) tea.Cmd {
+// Command to stop recording and close the file so it isn't truncated
+func stopRecording(p *player.Player, file *os.File) tea.Cmd {
 	return func() tea.Msg {
-		if cmd != nil && cmd.Process != nil {
-			if err := cmd.Process.Kill(); err != nil {
-				return playbackErrorMsg{err}
-			}
+		if err := p.StopRecording(); err != nil {
+			file.Close()
+			return recordingErrorMsg{err}
 		}
-		return stopPlaybackMsg{}
+		if err := file.Close(); err != nil {
+			return recordingErrorMsg{err}
+		}
+		return stopRecordingMsg{}
 	}
 }
 
@@ -161,13 +208,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
-			if m.player != nil {
-				return m, tea.Sequence(
-					stopPlayback(m.player),
-					tea.Quit,
-				)
+			var cmds []tea.Cmd
+			if m.Recording != nil {
+				cmds = append(cmds, stopRecording(m.player, m.Recording))
+			}
+			if m.playerState != stopped {
+				cmds = append(cmds, stopPlayback(m.player))
 			}
-			return m, tea.Quit
+			cmds = append(cmds, tea.Quit)
+			return m, tea.Sequence(cmds...)
 
 		case "up", "k":
 			if m.cursor > 0 {
@@ -175,50 +224,121 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "down", "j":
-			if m.cursor < len(m.channels)-1 {
+			if m.cursor < len(m.visibleChannels())-1 {
 				m.cursor++
 			}
 
+		case "tab":
+			m.view = (m.view + 1) % 3
+			m.cursor = 0
+
+		case "f":
+			visible := m.visibleChannels()
+			if m.cursor < len(visible) {
+				m.cfg.ToggleFavorite(visible[m.cursor].ID)
+				_ = m.cfg.Save() // best-effort; a failed write just means the star doesn't stick
+				if m.view == viewFavorites && m.cursor >= len(m.visibleChannels()) && m.cursor > 0 {
+					m.cursor--
+				}
+			}
+
 		case "enter", " ":
-			if m.playerState == playing && m.selected == &m.channels[m.cursor] {
+			visible := m.visibleChannels()
+			if m.cursor >= len(visible) {
+				return m, nil
+			}
+			channel := visible[m.cursor]
+
+			if m.playerState == playing && m.selected != nil && m.selected.ID == channel.ID {
 				m.selected = nil
-				return m, stopPlayback(m.player)
+				var cmds []tea.Cmd
+				if m.Recording != nil {
+					cmds = append(cmds, stopRecording(m.player, m.Recording))
+				}
+				cmds = append(cmds, stopPlayback(m.player))
+				return m, tea.Sequence(cmds...)
 			}
 
 			var cmds []tea.Cmd
-			if m.player != nil {
+			if m.Recording != nil {
+				// Switching channels mid-recording would otherwise keep
+				// writing the new channel's audio into the old channel's file.
+				cmds = append(cmds, stopRecording(m.player, m.Recording))
+			}
+			if m.playerState != stopped {
 				cmds = append(cmds, stopPlayback(m.player))
 			}
 
-			m.selected = &m.channels[m.cursor]
-			streamURL, err := getStreamURL(m.selected.FastPLS)
+			m.selected = &channel
+			m.nowPlaying = nowPlayingMsg{}
+			streamURL, err := somafm.StreamURL(channel.FastPLS)
 			if err != nil {
 				return m, func() tea.Msg {
 					return errMsg{err}
 				}
 			}
 
-			cmds = append(cmds, startPlayback(streamURL))
+			if percent, ok := m.cfg.Volume(channel.ID); ok {
+				m.player.SetVolumePercent(percent)
+			}
+			m.cfg.RecordPlay(channel.ID, time.Now())
+			_ = m.cfg.Save()
+
+			cmds = append(cmds, startPlayback(m.player, streamURL))
 			return m, tea.Sequence(cmds...)
+
+		case "r":
+			if m.recordDir == "" || m.playerState != playing || m.selected == nil {
+				return m, nil
+			}
+			if m.Recording != nil {
+				return m, stopRecording(m.player, m.Recording)
+			}
+			return m, startRecording(m.player, m.recordDir, m.selected.ID)
 		}
 
 	case startPlaybackMsg:
 		m.playerState = playing
-		m.player = msg.player
 
 	case stopPlaybackMsg:
 		m.playerState = stopped
-		m.player = nil
+		m.nowPlaying = nowPlayingMsg{}
 
 	case playbackErrorMsg:
 		m.err = msg.error
 		m.playerState = stopped
-		m.player = nil
+
+	case startRecordingMsg:
+		m.Recording = msg.file
+
+	case stopRecordingMsg:
+		m.Recording = nil
+
+	case recordingErrorMsg:
+		m.err = msg.error
+		m.Recording = nil
+
+	case nowPlayingMsg:
+		m.nowPlaying = msg
 
 	case channelsMsg:
 		m.channels = msg
 		m.loading = false
 
+		if m.resume && m.cfg.LastChannel != "" {
+			if idx, ok := indexByID(m.channels, m.cfg.LastChannel); ok {
+				channel := m.channels[idx]
+				m.cursor = idx
+				m.selected = &channel
+				if percent, ok := m.cfg.Volume(channel.ID); ok {
+					m.player.SetVolumePercent(percent)
+				}
+				if streamURL, err := somafm.StreamURL(channel.FastPLS); err == nil {
+					return m, startPlayback(m.player, streamURL)
+				}
+			}
+		}
+
 	case errMsg:
 		m.err = msg.error
 		m.loading = false
@@ -236,14 +356,24 @@ func (m model) View() string {
 		return errorStyle.Render(fmt.Sprintf("Error: %v\n", m.err))
 	}
 
-	s := titleStyle.Render("🎵 SomaFM Channels\n\n")
+	s := titleStyle.Render(fmt.Sprintf("🎵 SomaFM Channels — %s\n\n", m.view))
 
-	for i, channel := range m.channels {
+	visible := m.visibleChannels()
+	if len(visible) == 0 {
+		s += fmt.Sprintf("(no channels in %s)\n", m.view)
+	}
+
+	for i, channel := range visible {
 		cursor := "  "
 		if i == m.cursor {
 			cursor = "> "
 		}
 
+		star := " "
+		if m.cfg.IsFavorite(channel.ID) {
+			star = "★"
+		}
+
 		title := channel.Title
 		if len(title) > titleWidth-3 {
 			title = title[:titleWidth-3] + "..."
@@ -256,8 +386,9 @@ func (m model) View() string {
 		}
 		genre = fmt.Sprintf("%-*s", genreWidth, genre)
 
-		line := fmt.Sprintf("%s%s %s [%d]\n",
+		line := fmt.Sprintf("%s%s%s %s [%d]\n",
 			cursor,
+			star,
 			title,
 			genre,
 			channel.Listeners)
@@ -271,16 +402,74 @@ func (m model) View() string {
 
 	if m.playerState == playing && m.selected != nil {
 		s += "\n" + titleStyle.Render(fmt.Sprintf("Now Playing: %s", m.selected.Title))
+		if m.nowPlaying.title != "" {
+			track := m.nowPlaying.title
+			if m.nowPlaying.artist != "" {
+				track = fmt.Sprintf("%s - %s", m.nowPlaying.artist, track)
+			}
+			s += "\n" + selectedStyle.Render(track)
+		}
 	}
 
-	s += "\n(↑/↓) Navigate • (enter) Play/Stop • (q) Quit\n"
+	if m.Recording != nil {
+		bytesWritten, elapsed, _ := m.player.RecordingStats()
+		s += "\n" + selectedStyle.Render(fmt.Sprintf("● Recording %s (%d bytes, %s)",
+			filepath.Base(m.Recording.Name()), bytesWritten, elapsed.Round(time.Second)))
+	}
+
+	help := "\n(↑/↓) Navigate • (enter) Play/Stop • (f) Favorite • (tab) All/Favorites/Recent"
+	if m.recordDir != "" {
+		help += " • (r) Record"
+	}
+	help += " • (q) Quit\n"
+	s += help
 
 	return s
 }
 
 func main() {
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
+	recordDir := flag.String("record-dir", "", "directory to save recordings to when (r) is pressed")
+	listen := flag.String("listen", "", "run headless, serving the HTTP control API on this address instead of the TUI")
+	resume := flag.Bool("resume", false, "automatically play the last channel played on startup")
+	flag.Parse()
+
+	if *listen != "" {
+		runHeadless(*listen)
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	m := initialModel(*recordDir, *resume, cfg)
+	prog := tea.NewProgram(m)
+
+	m.player.OnNowPlaying(func(artist, title string) {
+		prog.Send(nowPlayingMsg{artist, title})
+	})
+
+	if _, err := prog.Run(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runHeadless serves the HTTP control API on addr, sharing the same
+// player.Player the TUI uses so behavior is identical either way.
+func runHeadless(addr string) {
+	channels, err := somafm.FetchChannels()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := api.New(player.New(), channels)
+
+	fmt.Printf("Listening on %s\n", addr)
+	if err := http.ListenAndServe(addr, srv.Routes()); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
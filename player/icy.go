@@ -0,0 +1,97 @@
+package player
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var streamTitleRe = regexp.MustCompile(`StreamTitle='([^']*)';`)
+
+// NowPlayingFunc receives the artist and title announced in an ICY
+// StreamTitle metadata block. artist is empty if the stream didn't
+// separate it with " - ".
+type NowPlayingFunc func(artist, title string)
+
+// openICYStream requests streamURL with Icy-MetaData: 1 so Shoutcast/Icecast
+// servers interleave metadata blocks into the response. metaint is the byte
+// interval between blocks, or 0 if the server doesn't support ICY metadata.
+func openICYStream(streamURL string) (body io.ReadCloser, metaint int, err error) {
+	req, err := http.NewRequest(http.MethodGet, streamURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	metaint, _ = strconv.Atoi(resp.Header.Get("icy-metaint"))
+	return resp.Body, metaint, nil
+}
+
+// icyReader strips inline ICY metadata blocks out of the audio stream
+// before they reach the MP3 decoder, invoking onMeta whenever a
+// StreamTitle is announced.
+type icyReader struct {
+	r       io.Reader
+	metaint int
+	toNext  int
+	onMeta  NowPlayingFunc
+}
+
+func newICYReader(r io.Reader, metaint int, onMeta NowPlayingFunc) *icyReader {
+	return &icyReader{r: r, metaint: metaint, toNext: metaint, onMeta: onMeta}
+}
+
+func (i *icyReader) Read(p []byte) (int, error) {
+	if i.toNext > 0 {
+		max := i.toNext
+		if max > len(p) {
+			max = len(p)
+		}
+		n, err := i.r.Read(p[:max])
+		i.toNext -= n
+		return n, err
+	}
+
+	var lenByte [1]byte
+	if _, err := io.ReadFull(i.r, lenByte[:]); err != nil {
+		return 0, err
+	}
+
+	if metaLen := int(lenByte[0]) * 16; metaLen > 0 {
+		meta := make([]byte, metaLen)
+		if _, err := io.ReadFull(i.r, meta); err != nil {
+			return 0, err
+		}
+		i.announce(string(meta))
+	}
+
+	i.toNext = i.metaint
+	return i.Read(p)
+}
+
+func (i *icyReader) announce(meta string) {
+	match := streamTitleRe.FindStringSubmatch(meta)
+	if match == nil || i.onMeta == nil {
+		return
+	}
+
+	artist, title := "", match[1]
+	if idx := strings.Index(match[1], " - "); idx != -1 {
+		artist, title = match[1][:idx], match[1][idx+len(" - "):]
+	}
+	i.onMeta(artist, title)
+}
+
+// readCloser pairs an ICY-stripped reader with the underlying response
+// body so the combination can still be closed.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,126 @@
+package player
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// buildICYFrame wraps audio chunks with ICY metadata blocks at metaint-byte
+// intervals, mirroring what a Shoutcast/Icecast server sends when
+// Icy-MetaData: 1 is set. Each entry in metas is announced after the audio
+// chunk at the same index; pass "" for chunks with no announcement.
+func buildICYFrame(metaint int, audio []byte, metas []string) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(audio); i += metaint {
+		end := i + metaint
+		if end > len(audio) {
+			end = len(audio)
+		}
+		buf.Write(audio[i:end])
+
+		meta := metas[i/metaint]
+		if meta == "" {
+			buf.WriteByte(0)
+			continue
+		}
+		padded := meta
+		if pad := len(meta) % 16; pad != 0 {
+			padded += string(make([]byte, 16-pad))
+		}
+		buf.WriteByte(byte(len(padded) / 16))
+		buf.WriteString(padded)
+	}
+	return buf.Bytes()
+}
+
+func TestICYReaderStripsMetadataAcrossBoundaries(t *testing.T) {
+	audio := []byte("0123456789abcdef")
+	metaint := 4
+	metas := []string{
+		"",
+		"StreamTitle='Artist One - Track One';",
+		"",
+		"StreamTitle='Track With No Artist';",
+	}
+	framed := buildICYFrame(metaint, audio, metas)
+
+	var announced []string
+	r := newICYReader(bytes.NewReader(framed), metaint, func(artist, title string) {
+		announced = append(announced, artist+"|"+title)
+	})
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, audio) {
+		t.Fatalf("stripped stream = %q, want %q", got, audio)
+	}
+
+	want := []string{"Artist One|Track One", "|Track With No Artist"}
+	if len(announced) != len(want) {
+		t.Fatalf("announced = %v, want %v", announced, want)
+	}
+	for i, w := range want {
+		if announced[i] != w {
+			t.Errorf("announced[%d] = %q, want %q", i, announced[i], w)
+		}
+	}
+}
+
+func TestICYReaderSmallReadsAcrossMetaBoundary(t *testing.T) {
+	audio := []byte("abcdefgh")
+	metaint := 4
+	metas := []string{"StreamTitle='X - Y';", ""}
+	framed := buildICYFrame(metaint, audio, metas)
+
+	r := newICYReader(bytes.NewReader(framed), metaint, nil)
+
+	var got []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+	}
+	if !bytes.Equal(got, audio) {
+		t.Fatalf("stripped stream = %q, want %q", got, audio)
+	}
+}
+
+func TestAnnounceSplitsArtistAndTitle(t *testing.T) {
+	cases := []struct {
+		meta       string
+		wantArtist string
+		wantTitle  string
+		wantCalled bool
+	}{
+		{"StreamTitle='Daft Punk - Harder';", "Daft Punk", "Harder", true},
+		{"StreamTitle='No Separator Here';", "", "No Separator Here", true},
+		{"garbage, no StreamTitle field", "", "", false},
+	}
+
+	for _, c := range cases {
+		var gotArtist, gotTitle string
+		called := false
+		i := &icyReader{onMeta: func(artist, title string) {
+			called = true
+			gotArtist, gotTitle = artist, title
+		}}
+		i.announce(c.meta)
+
+		if called != c.wantCalled {
+			t.Errorf("announce(%q) called = %v, want %v", c.meta, called, c.wantCalled)
+			continue
+		}
+		if called && (gotArtist != c.wantArtist || gotTitle != c.wantTitle) {
+			t.Errorf("announce(%q) = (%q, %q), want (%q, %q)", c.meta, gotArtist, gotTitle, c.wantArtist, c.wantTitle)
+		}
+	}
+}
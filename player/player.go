@@ -0,0 +1,340 @@
+// Package player implements an in-process audio pipeline for SomaFM
+// streams, decoding and mixing audio directly instead of shelling out to
+// an external player.
+package player
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/mp3"
+	"github.com/faiface/beep/speaker"
+)
+
+// State describes what the player is currently doing.
+type State int
+
+const (
+	Stopped State = iota
+	Playing
+	Paused
+)
+
+// Player decodes a single SomaFM stream at a time through a beep.Mixer,
+// detaching the previous stream's entry before mixing in the next one so
+// channel switches don't overlap audio.
+type Player struct {
+	mu            sync.Mutex
+	mixer         beep.Mixer
+	ctrl          *beep.Ctrl
+	volume        *effects.Volume
+	entry         *trackEntry
+	body          io.Closer
+	sampleRate    beep.SampleRate
+	state         State
+	ready         bool
+	recorder      *recorder
+	nowPlaying    NowPlayingFunc
+	volumePercent int
+}
+
+// trackEntry wraps a streamer added to the mixer so it can be detached on
+// demand. beep.Mixer only drops a streamer once its Stream call reports
+// fewer samples than requested with ok=false; a merely paused beep.Ctrl
+// keeps reporting ok=true forever and is never pruned. markDone flips
+// that signal on the entry's next Stream call, so switching channels or
+// stopping playback actually removes the old entry instead of leaving it
+// mixed in forever.
+type trackEntry struct {
+	mu   sync.Mutex
+	s    beep.Streamer
+	done bool
+}
+
+func (t *trackEntry) Stream(samples [][2]float64) (n int, ok bool) {
+	t.mu.Lock()
+	done := t.done
+	t.mu.Unlock()
+	if done {
+		return 0, false
+	}
+	return t.s.Stream(samples)
+}
+
+func (t *trackEntry) Err() error {
+	return t.s.Err()
+}
+
+func (t *trackEntry) markDone() {
+	t.mu.Lock()
+	t.done = true
+	t.mu.Unlock()
+}
+
+// New returns an idle Player. Call Init before Select.
+func New() *Player {
+	return &Player{volumePercent: 100}
+}
+
+// Init starts the speaker at the given sample rate. It is safe to call
+// more than once; only the first call takes effect.
+func (p *Player) Init(sampleRate beep.SampleRate) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ready {
+		return nil
+	}
+
+	if err := speaker.Init(sampleRate, sampleRate.N(time.Second/10)); err != nil {
+		return fmt.Errorf("init speaker: %w", err)
+	}
+	speaker.Play(&p.mixer)
+	p.ready = true
+	return nil
+}
+
+// Select fetches streamURL and mixes it in at the volume last set via
+// SetVolumePercent, detaching and closing whatever was playing before it.
+// If the server announces ICY metadata, now-playing updates are reported
+// through the callback set by OnNowPlaying.
+func (p *Player) Select(streamURL string) error {
+	body, metaint, err := openICYStream(streamURL)
+	if err != nil {
+		return fmt.Errorf("fetch stream: %w", err)
+	}
+
+	var src io.Reader = body
+	if metaint > 0 {
+		src = newICYReader(body, metaint, p.notifyNowPlaying)
+	}
+
+	streamer, format, err := mp3.Decode(readCloser{Reader: src, Closer: body})
+	if err != nil {
+		body.Close()
+		return fmt.Errorf("decode stream: %w", err)
+	}
+
+	if err := p.Init(format.SampleRate); err != nil {
+		streamer.Close()
+		return err
+	}
+
+	ctrl := &beep.Ctrl{Streamer: &teeStreamer{Streamer: streamer, p: p}}
+	volume := &effects.Volume{Streamer: ctrl, Base: decibelBase}
+	entry := &trackEntry{s: volume}
+
+	p.mu.Lock()
+	volume.Volume = decibelsFromPercent(p.volumePercent)
+	prevEntry, prevBody := p.entry, p.body
+	p.ctrl, p.volume, p.entry, p.body = ctrl, volume, entry, body
+	p.sampleRate = format.SampleRate
+	p.state = Playing
+	p.mu.Unlock()
+
+	speaker.Lock()
+	if prevEntry != nil {
+		prevEntry.markDone()
+	}
+	p.mixer.Add(entry)
+	speaker.Unlock()
+
+	if prevBody != nil {
+		prevBody.Close()
+	}
+
+	return nil
+}
+
+// OnNowPlaying registers a callback invoked whenever the current stream
+// announces a new StreamTitle. It is called from the audio pipeline's own
+// goroutine, so the callback must be safe to call concurrently.
+func (p *Player) OnNowPlaying(fn NowPlayingFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nowPlaying = fn
+}
+
+func (p *Player) notifyNowPlaying(artist, title string) {
+	p.mu.Lock()
+	cb := p.nowPlaying
+	p.mu.Unlock()
+	if cb != nil {
+		cb(artist, title)
+	}
+}
+
+// Pause suspends the current stream without releasing it.
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ctrl == nil {
+		return
+	}
+	speaker.Lock()
+	p.ctrl.Paused = true
+	speaker.Unlock()
+	p.state = Paused
+}
+
+// Resume continues a paused stream.
+func (p *Player) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ctrl == nil {
+		return
+	}
+	speaker.Lock()
+	p.ctrl.Paused = false
+	speaker.Unlock()
+	p.state = Playing
+}
+
+// Stop halts playback, detaches the current stream from the mixer, and
+// releases it.
+func (p *Player) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.entry != nil {
+		speaker.Lock()
+		p.entry.markDone()
+		speaker.Unlock()
+	}
+	if p.body != nil {
+		p.body.Close()
+	}
+	p.ctrl = nil
+	p.volume = nil
+	p.entry = nil
+	p.body = nil
+	p.state = Stopped
+}
+
+// Volume sets playback volume in decibels of gain, where 0 is unity.
+// Negative values are quieter, positive values louder.
+func (p *Player) Volume(db float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.volume == nil {
+		return
+	}
+	speaker.Lock()
+	p.volume.Volume = db
+	speaker.Unlock()
+}
+
+// SetVolumePercent sets playback volume as a 0-100 percentage, where 100
+// is unity gain.
+func (p *Player) SetVolumePercent(percent int) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+
+	p.mu.Lock()
+	p.volumePercent = percent
+	p.mu.Unlock()
+
+	p.Volume(decibelsFromPercent(percent))
+}
+
+// VolumePercent reports the last volume set via SetVolumePercent.
+func (p *Player) VolumePercent() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volumePercent
+}
+
+// decibelBase is the effects.Volume base under which Base^Volume equals
+// the standard base-10 amplitude ratio 10^(dB/20), matching the decibel
+// scale decibelsFromPercent computes on.
+var decibelBase = math.Pow(10, 1.0/20)
+
+// decibelsFromPercent maps a 0-100 volume percentage onto the decibel
+// scale Volume expects.
+func decibelsFromPercent(percent int) float64 {
+	if percent <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(float64(percent)/100)
+}
+
+// State reports what the player is currently doing.
+func (p *Player) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// StartRecording begins teeing decoded PCM into an MP3 encoder writing to
+// w, alongside whatever is already playing. w is not closed by the
+// player; the caller owns it.
+func (p *Player) StartRecording(w io.Writer) error {
+	p.mu.Lock()
+	sampleRate := p.sampleRate
+	p.mu.Unlock()
+
+	if sampleRate == 0 {
+		return fmt.Errorf("no active stream to record")
+	}
+
+	rec, err := newRecorder(w, int(sampleRate))
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.recorder = rec
+	p.mu.Unlock()
+	return nil
+}
+
+// StopRecording flushes and finalizes the current recording, if any.
+// Playback is unaffected.
+func (p *Player) StopRecording() error {
+	p.mu.Lock()
+	rec := p.recorder
+	p.mu.Unlock()
+
+	if rec == nil {
+		return nil
+	}
+
+	// teeStreamer.Stream runs on the audio callback under speaker's
+	// internal lock and calls rec.write after releasing p.mu, so p.mu
+	// alone doesn't stop a write from landing after close flushes the
+	// trailing frame. Taking speaker.Lock blocks until any in-flight
+	// Stream call (and its write) has returned, so clearing p.recorder
+	// here guarantees no further write reaches rec once we unlock.
+	speaker.Lock()
+	p.mu.Lock()
+	p.recorder = nil
+	p.mu.Unlock()
+	speaker.Unlock()
+
+	return rec.close()
+}
+
+// RecordingStats reports bytes written and elapsed time for the active
+// recording. active is false if nothing is being recorded.
+func (p *Player) RecordingStats() (bytesWritten int64, elapsed time.Duration, active bool) {
+	p.mu.Lock()
+	rec := p.recorder
+	p.mu.Unlock()
+
+	if rec == nil {
+		return 0, 0, false
+	}
+	bytesWritten, elapsed = rec.stats()
+	return bytesWritten, elapsed, true
+}
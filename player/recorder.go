@@ -0,0 +1,94 @@
+package player
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/viert/lame"
+)
+
+// The go-lame example this mirrors re-encodes mono PCM to 44.1 kHz stereo
+// MP3 out at the highest quality setting; the input rate is whatever the
+// stream actually decoded at, passed into newRecorder.
+const (
+	recordOutSampleRate = 44100
+	recordQuality       = 0
+)
+
+// recorder tees decoded PCM samples into an MP3 encoder while playback
+// continues untouched.
+type recorder struct {
+	mu      sync.Mutex
+	enc     *lame.LameWriter
+	started time.Time
+	written int64
+}
+
+// newRecorder wraps w with an MP3 encoder configured like the go-lame
+// broadcast example, reading PCM at inSampleRate.
+func newRecorder(w io.Writer, inSampleRate int) (*recorder, error) {
+	enc := lame.NewWriter(w)
+	enc.Encoder.SetInSamplerate(inSampleRate)
+	enc.Encoder.SetNumChannels(1)
+	enc.Encoder.SetOutSamplerate(recordOutSampleRate)
+	enc.Encoder.SetOutNumChannels(2)
+	enc.Encoder.SetQuality(recordQuality)
+	if err := enc.Encoder.InitParams(); err != nil {
+		return nil, fmt.Errorf("init lame encoder: %w", err)
+	}
+	return &recorder{enc: enc, started: time.Now()}, nil
+}
+
+// write encodes samples down to mono 16-bit PCM and feeds it to lame.
+func (r *recorder) write(samples [][2]float64) {
+	buf := make([]byte, 0, len(samples)*2)
+	for _, s := range samples {
+		v := int16(beep.Clamp(-1, 1, (s[0]+s[1])/2) * 32767)
+		buf = append(buf, byte(v), byte(v>>8))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, err := r.enc.Write(buf)
+	if err == nil {
+		r.written += int64(n)
+	}
+}
+
+// close flushes the encoder and finalizes the MP3 file. It does not close
+// the underlying writer, which the caller owns.
+func (r *recorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Close()
+}
+
+// stats reports bytes written and elapsed recording time.
+func (r *recorder) stats() (bytesWritten int64, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.written, time.Since(r.started)
+}
+
+// teeStreamer copies every decoded sample to the player's active recorder,
+// if any, before handing it on to the rest of the beep chain.
+type teeStreamer struct {
+	beep.Streamer
+	p *Player
+}
+
+func (t *teeStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	n, ok = t.Streamer.Stream(samples)
+
+	t.p.mu.Lock()
+	rec := t.p.recorder
+	t.p.mu.Unlock()
+
+	if rec != nil && n > 0 {
+		rec.write(samples[:n])
+	}
+	return n, ok
+}
@@ -0,0 +1,92 @@
+// Package somafm fetches and parses SomaFM's channel directory and
+// resolves a channel's PLS playlist to a playable stream URL. It has no
+// dependency on how the result is presented, so the TUI and the HTTP
+// control API both build on it.
+package somafm
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Channel represents a SomaFM channel
+type Channel struct {
+	ID          string `xml:"id,attr"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Genre       string `xml:"genre"`
+	Image       string `xml:"image"`
+	DJ          string `xml:"dj"`
+	Listeners   int    `xml:"listeners"`
+	FastPLS     string `xml:"fastpls"`
+}
+
+// Channels represents the root XML element
+type Channels struct {
+	XMLName  xml.Name  `xml:"channels"`
+	Channels []Channel `xml:"channel"`
+}
+
+// FetchChannels downloads and parses SomaFM's channel directory.
+func FetchChannels() ([]Channel, error) {
+	resp, err := http.Get("https://somafm.com/channels.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	var channels Channels
+	if err := decoder.Decode(&channels); err != nil {
+		return nil, err
+	}
+
+	for i := range channels.Channels {
+		channels.Channels[i].Description = strings.TrimSpace(channels.Channels[i].Description)
+		if fastPLS := channels.Channels[i].FastPLS; fastPLS != "" {
+			if idx := strings.Index(fastPLS, "\n"); idx != -1 {
+				channels.Channels[i].FastPLS = fastPLS[:idx]
+			}
+		}
+	}
+
+	return channels.Channels, nil
+}
+
+// Find returns the channel with the given ID, or false if none matches.
+func Find(channels []Channel, id string) (Channel, bool) {
+	for _, ch := range channels {
+		if ch.ID == id {
+			return ch, true
+		}
+	}
+	return Channel{}, false
+}
+
+// StreamURL resolves a channel's PLS playlist to its actual stream URL.
+func StreamURL(plsURL string) (string, error) {
+	resp, err := http.Get(plsURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	re := regexp.MustCompile(`File1=(.+)`)
+
+	for scanner.Scan() {
+		if matches := re.FindStringSubmatch(scanner.Text()); len(matches) > 1 {
+			return matches[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("no stream URL found in PLS file")
+}